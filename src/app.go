@@ -0,0 +1,257 @@
+package main
+
+// Subcommand framework: one subcommand per top-level keyword in
+// .fimov.json, auto-registered from the config so adding a new source
+// doesn't require touching Go code, plus global commands for scaffolding
+// and inspecting that config.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const configFileName = ".fimov.json"
+
+func newApp() *cli.App {
+	return &cli.App{
+		Name:  "fimov",
+		Usage: "organize images and videos into dated folders by capture date",
+		Commands: append([]*cli.Command{
+			initCommand(),
+			listCommand(),
+			validateCommand(),
+			undoCommand(),
+		}, keywordCommands()...),
+	}
+}
+
+// keywordCommands builds one subcommand per top-level key in .fimov.json.
+// If the config can't be read yet (e.g. "fimov init" hasn't run), it
+// returns no keyword commands rather than failing app construction.
+func keywordCommands() []*cli.Command {
+	config, err := readConfig(configFileName)
+	if err != nil {
+		return nil
+	}
+
+	commands := make([]*cli.Command, 0, len(config))
+	for keyword, conf := range config {
+		commands = append(commands, keywordCommand(keyword, conf))
+	}
+	return commands
+}
+
+func keywordCommand(keyword string, conf PathConfig) *cli.Command {
+	return &cli.Command{
+		Name:  keyword,
+		Usage: fmt.Sprintf("organize files configured under %q", keyword),
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "start", Required: true, Usage: "Start date (format: YYYY-MM-DD)"},
+			&cli.StringFlag{Name: "end", Usage: "End date (format: YYYY-MM-DD), default now"},
+			&cli.StringFlag{Name: "name", Usage: "Folder name, default <start>_<end>"},
+			&cli.StringFlag{Name: "layout", Usage: "Destination layout (flat, dated, cas)"},
+			&cli.BoolFlag{Name: "dedup", Usage: "Skip/link duplicate files instead of copying them again"},
+			&cli.IntFlag{Name: "workers", Value: defaultWorkers, Usage: "Number of mover workers"},
+			&cli.IntFlag{Name: "parse-workers", Value: defaultParseWorkers, Usage: "Number of date-resolving parser workers"},
+			&cli.StringFlag{Name: "verify", Value: verifyNone, Usage: "Verify cross-device copies (none, size, sha256)"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "Print planned moves without touching the filesystem"},
+			&cli.StringFlag{Name: "extensions", Usage: "Comma-separated list of extensions to move (e.g. .jpg,.mp4)"},
+			&cli.StringFlag{Name: "date-source", Usage: "Comma-separated date source order (exif,xmp,container,mtime)"},
+		},
+		Action: func(c *cli.Context) error {
+			return runKeyword(keyword, conf, c)
+		},
+	}
+}
+
+func runKeyword(keyword string, conf PathConfig, c *cli.Context) error {
+	destFs, err := newFs(conf)
+	if err != nil {
+		return fmt.Errorf("setting up destination backend for %s: %w", keyword, err)
+	}
+
+	if err := validatePaths(conf, destFs); err != nil {
+		return err
+	}
+
+	start := c.String("start")
+	end := c.String("end")
+	if end == "" {
+		end = time.Now().Format("2006-01-02")
+	}
+
+	name := c.String("name")
+	if name == "" {
+		name = fmt.Sprintf("%s_%s", start, end)
+	}
+
+	startDate, err := parseDate(start)
+	if err != nil {
+		return err
+	}
+
+	endDate, err := parseDate(end)
+	if err != nil {
+		return err
+	}
+
+	layout := c.String("layout")
+	if layout == "" {
+		layout = conf.Layout
+	}
+	if layout == "" {
+		layout = layoutFlat
+	}
+
+	dedup := conf.Dedup || c.Bool("dedup")
+
+	dateSources := conf.DateSources
+	if ds := c.String("date-source"); ds != "" {
+		dateSources = strings.Split(ds, ",")
+	}
+
+	extensions := conf.Extensions
+	if exts := c.String("extensions"); exts != "" {
+		extensions = strings.Split(exts, ",")
+	}
+
+	destPath := filepath.Join(conf.Destination, name)
+
+	dryRun := c.Bool("dry-run")
+
+	var journal *Journal
+	if dryRun {
+		fmt.Println("Dry run: no files will be moved and no journal will be written.")
+	} else {
+		if err := destFs.MkdirAll(destPath, os.ModePerm); err != nil {
+			return fmt.Errorf("creating destination directory: %w", err)
+		}
+
+		journal, err = openJournal(destPath, conf)
+		if err != nil {
+			return fmt.Errorf("opening journal: %w", err)
+		}
+	}
+
+	opts := pipelineOptions{
+		Workers:      c.Int("workers"),
+		ParseWorkers: c.Int("parse-workers"),
+		Verify:       c.String("verify"),
+		DryRun:       dryRun,
+		Journal:      journal,
+		Extensions:   extensions,
+	}
+
+	dupBytes, err := moveImages(destFs, conf.Source, destPath, startDate, endDate, dateSources, layout, dedup, opts)
+	if err != nil {
+		return fmt.Errorf("organizing images: %w", err)
+	}
+
+	fmt.Println("Images organized successfully.")
+	if dedup && dupBytes > 0 {
+		fmt.Printf("Dedup saved %d bytes.\n", dupBytes)
+	}
+	return nil
+}
+
+func initCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "init",
+		Usage: "scaffold a " + configFileName + " in the current directory",
+		Action: func(c *cli.Context) error {
+			if _, err := os.Stat(configFileName); err == nil {
+				return fmt.Errorf("%s already exists", configFileName)
+			}
+
+			scaffold := map[string]PathConfig{
+				"camera": {Source: "your-source-path", Destination: "your-destination-path"},
+			}
+
+			data, err := json.MarshalIndent(scaffold, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(configFileName, data, 0o644); err != nil {
+				return err
+			}
+
+			fmt.Println("Wrote", configFileName)
+			return nil
+		},
+	}
+}
+
+func listCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "show the keywords configured in " + configFileName,
+		Action: func(c *cli.Context) error {
+			config, err := readConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			for keyword, conf := range config {
+				fmt.Printf("%s: %s -> %s\n", keyword, conf.Source, conf.Destination)
+			}
+			return nil
+		},
+	}
+}
+
+func validateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "validate",
+		Usage: "check that every configured source/destination exists and is writable",
+		Action: func(c *cli.Context) error {
+			config, err := readConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			var failed bool
+			for keyword, conf := range config {
+				destFs, err := newFs(conf)
+				if err != nil {
+					fmt.Printf("%s: %v\n", keyword, err)
+					failed = true
+					continue
+				}
+
+				if err := validatePaths(conf, destFs); err != nil {
+					fmt.Printf("%s: %v\n", keyword, err)
+					failed = true
+					continue
+				}
+
+				fmt.Printf("%s: ok\n", keyword)
+			}
+
+			if failed {
+				return fmt.Errorf("one or more keywords failed validation")
+			}
+			return nil
+		},
+	}
+}
+
+func undoCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "undo",
+		Usage:     "replay a journal in reverse, moving files back to their original locations",
+		ArgsUsage: "<journal-file>",
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("usage: fimov undo <journal-file>")
+			}
+			return runUndo(c.Args().First())
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chdir switches the test process into dir for the duration of the test,
+// restoring the original working directory on cleanup. Needed because
+// readConfig/validateCommand always look for .fimov.json in the cwd.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(orig); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func writeConfig(t *testing.T, dir string, config map[string]PathConfig) {
+	t.Helper()
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, configFileName), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateCommandOK(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	destination := filepath.Join(dir, "destination")
+	if err := os.MkdirAll(source, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(destination, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dir)
+	writeConfig(t, dir, map[string]PathConfig{
+		"camera": {Source: source, Destination: destination},
+	})
+
+	if err := newApp().Run([]string{"fimov", "validate"}); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+}
+
+func TestValidateCommandMissingSource(t *testing.T) {
+	dir := t.TempDir()
+	destination := filepath.Join(dir, "destination")
+	if err := os.MkdirAll(destination, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dir)
+	writeConfig(t, dir, map[string]PathConfig{
+		"camera": {Source: filepath.Join(dir, "does-not-exist"), Destination: destination},
+	})
+
+	if err := newApp().Run([]string{"fimov", "validate"}); err == nil {
+		t.Error("validate with missing source: want error, got nil")
+	}
+}
+
+func TestKeywordCommandRequiresStart(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	destination := filepath.Join(dir, "destination")
+	if err := os.MkdirAll(source, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(destination, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dir)
+	writeConfig(t, dir, map[string]PathConfig{
+		"camera": {Source: source, Destination: destination},
+	})
+
+	if err := newApp().Run([]string{"fimov", "camera"}); err == nil {
+		t.Error("camera subcommand without --start: want error, got nil")
+	}
+}
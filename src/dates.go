@@ -0,0 +1,247 @@
+package main
+
+// Date resolution for moveImages.
+//
+// Filesystem mtime is unreliable (copies, syncs, and WhatsApp re-encoding all
+// rewrite it), so we prefer timestamps embedded in the file itself. Each
+// keyword configures an ordered list of sources to try via "date_sources";
+// the first source that successfully yields a date wins.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// macEpoch is the MP4/MOV container epoch (1904-01-01), used to convert mvhd
+// creation times into Go's time.Time.
+var macEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Supported values for the per-keyword "date_sources" config field.
+const (
+	dateSourceExif      = "exif"
+	dateSourceXMP       = "xmp"
+	dateSourceContainer = "container"
+	dateSourceMtime     = "mtime"
+)
+
+// defaultDateSources is used when a keyword does not configure "date_sources".
+var defaultDateSources = []string{dateSourceExif, dateSourceXMP, dateSourceContainer, dateSourceMtime}
+
+// exifDateLayout is the timestamp format used by the EXIF DateTimeOriginal tag.
+const exifDateLayout = "2006:01:02 15:04:05"
+
+// resolveDate determines the capture date of the file at path by trying each
+// source in order, returning the date and the name of the source that
+// produced it.
+func resolveDate(path string, info os.FileInfo, sources []string) (time.Time, string, error) {
+	if len(sources) == 0 {
+		sources = defaultDateSources
+	}
+
+	for _, source := range sources {
+		switch source {
+		case dateSourceExif:
+			if t, err := dateFromExif(path); err == nil {
+				return t, dateSourceExif, nil
+			}
+		case dateSourceXMP:
+			if t, err := dateFromXMPSidecar(path); err == nil {
+				return t, dateSourceXMP, nil
+			}
+		case dateSourceContainer:
+			if t, err := dateFromContainer(path); err == nil {
+				return t, dateSourceContainer, nil
+			}
+		case dateSourceMtime:
+			return info.ModTime(), dateSourceMtime, nil
+		default:
+			return time.Time{}, "", fmt.Errorf("unknown date source: %s", source)
+		}
+	}
+
+	// No configured source produced a date; mtime never fails, so only
+	// reach here if "mtime" was omitted from the list entirely.
+	return info.ModTime(), dateSourceMtime, nil
+}
+
+// dateFromExif reads the DateTimeOriginal tag from a JPEG/HEIC/PNG file.
+// goexif only understands TIFF/JPEG-style EXIF blocks, not HEIC's ISOBMFF
+// box container or PNG's chunk container, so those two fall back to
+// shelling out to exiftool (the same last-resort pattern dateFromContainer
+// uses for ffprobe).
+func dateFromExif(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	x, err := exif.Decode(f)
+	if err == nil {
+		return x.DateTime()
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".heic", ".heif", ".png":
+		return exiftoolDateTimeOriginal(path)
+	default:
+		return time.Time{}, err
+	}
+}
+
+// exiftoolDateTimeOriginal shells out to exiftool for formats goexif cannot
+// parse directly.
+func exiftoolDateTimeOriginal(path string) (time.Time, error) {
+	// exiftool's -d takes a strftime format; this one prints the same
+	// layout as exifDateLayout.
+	out, err := exec.Command("exiftool", "-DateTimeOriginal", "-s3", "-d", "%Y:%m:%d %H:%M:%S", path).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("exiftool unavailable or failed for %s: %w", path, err)
+	}
+
+	return time.Parse(exifDateLayout, strings.TrimSpace(string(out)))
+}
+
+// dateFromXMPSidecar looks for a "<name>.xmp" file next to path and extracts
+// exif:DateTimeOriginal or photoshop:DateCreated from it.
+func dateFromXMPSidecar(path string) (time.Time, error) {
+	sidecar := path + ".xmp"
+	if _, err := os.Stat(sidecar); err != nil {
+		ext := filepath.Ext(path)
+		sidecar = strings.TrimSuffix(path, ext) + ".xmp"
+		if _, err := os.Stat(sidecar); err != nil {
+			return time.Time{}, fmt.Errorf("no xmp sidecar for %s", path)
+		}
+	}
+
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	match := xmpDateRe.FindSubmatch(data)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("no date found in xmp sidecar %s", sidecar)
+	}
+
+	return time.Parse(time.RFC3339, string(match[1]))
+}
+
+var xmpDateRe = regexp.MustCompile(`(?:exif:DateTimeOriginal|photoshop:DateCreated)="([^"]+)"`)
+
+// dateFromContainer reads the creation time from MP4/MOV container metadata
+// (the moov/mvhd atom), falling back to ffprobe if it is available on PATH.
+func dateFromContainer(path string) (time.Time, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".mp4" && ext != ".mov" && ext != ".m4v" {
+		return time.Time{}, fmt.Errorf("%s is not a supported container format", path)
+	}
+
+	if t, err := mvhdCreationTime(path); err == nil {
+		return t, nil
+	}
+
+	return ffprobeCreationTime(path)
+}
+
+// mvhdCreationTime walks the top-level MP4/MOV atoms looking for moov/mvhd
+// and returns the creation time stored there (seconds since 1904-01-01).
+func mvhdCreationTime(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return time.Time{}, fmt.Errorf("moov/mvhd atom not found in %s", path)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+
+		if boxType == "moov" {
+			return findMvhd(io.LimitReader(r, size-8))
+		}
+
+		if size < 8 {
+			return time.Time{}, fmt.Errorf("malformed atom in %s", path)
+		}
+		if _, err := r.Discard(int(size - 8)); err != nil {
+			return time.Time{}, fmt.Errorf("moov/mvhd atom not found in %s", path)
+		}
+	}
+}
+
+// findMvhd scans the children of a moov atom for mvhd and returns its
+// creation time. Both version 0 (32-bit) and version 1 (64-bit) layouts are
+// supported.
+func findMvhd(r io.Reader) (time.Time, error) {
+	br := bufio.NewReader(r)
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(br, header); err != nil {
+			return time.Time{}, fmt.Errorf("mvhd atom not found")
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+
+		if boxType != "mvhd" {
+			if size < 8 {
+				return time.Time{}, fmt.Errorf("malformed atom inside moov")
+			}
+			if _, err := br.Discard(int(size - 8)); err != nil {
+				return time.Time{}, fmt.Errorf("mvhd atom not found")
+			}
+			continue
+		}
+
+		versionAndFlags := make([]byte, 4)
+		if _, err := io.ReadFull(br, versionAndFlags); err != nil {
+			return time.Time{}, err
+		}
+
+		var creationSecs uint64
+		if versionAndFlags[0] == 1 {
+			buf := make([]byte, 8)
+			if _, err := io.ReadFull(br, buf); err != nil {
+				return time.Time{}, err
+			}
+			creationSecs = binary.BigEndian.Uint64(buf)
+		} else {
+			buf := make([]byte, 4)
+			if _, err := io.ReadFull(br, buf); err != nil {
+				return time.Time{}, err
+			}
+			creationSecs = uint64(binary.BigEndian.Uint32(buf))
+		}
+
+		return macEpoch.Add(time.Duration(creationSecs) * time.Second), nil
+	}
+}
+
+// ffprobeCreationTime shells out to ffprobe as a last resort for containers
+// whose mvhd atom could not be parsed directly.
+func ffprobeCreationTime(path string) (time.Time, error) {
+	out, err := exec.Command("ffprobe", "-v", "quiet", "-show_entries", "format_tags=creation_time",
+		"-of", "default=noprint_wrappers=1:nokey=1", path).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ffprobe unavailable or failed for %s: %w", path, err)
+	}
+
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+}
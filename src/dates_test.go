@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindMvhd(t *testing.T) {
+	want := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+	creationSecs := uint32(want.Sub(macEpoch).Seconds())
+
+	var mvhd bytes.Buffer
+	mvhd.Write([]byte{0, 0, 0, 0}) // version (0) + flags
+	var secs [4]byte
+	binary.BigEndian.PutUint32(secs[:], creationSecs)
+	mvhd.Write(secs[:])
+	mvhd.Write(make([]byte, 8)) // modification time + timescale, unused by findMvhd
+
+	var moov bytes.Buffer
+	writeAtom(&moov, "free", []byte{1, 2, 3}) // unrelated atom that must be skipped
+	writeAtom(&moov, "mvhd", mvhd.Bytes())
+
+	got, err := findMvhd(&moov)
+	if err != nil {
+		t.Fatalf("findMvhd: %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("findMvhd() = %v, want %v", got, want)
+	}
+}
+
+func TestFindMvhdNotFound(t *testing.T) {
+	var moov bytes.Buffer
+	writeAtom(&moov, "free", []byte{1, 2, 3})
+
+	if _, err := findMvhd(&moov); err == nil {
+		t.Error("findMvhd() with no mvhd atom: want error, got nil")
+	}
+}
+
+// writeAtom appends a box-length-prefixed atom to buf, as used by MP4/MOV
+// containers and expected by mvhdCreationTime/findMvhd.
+func writeAtom(buf *bytes.Buffer, boxType string, payload []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(8+len(payload)))
+	copy(header[4:8], boxType)
+	buf.Write(header[:])
+	buf.Write(payload)
+}
+
+func TestDateFromXMPSidecar(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(imgPath, []byte("not a real jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	xmp := `<x:xmpmeta><rdf:Description exif:DateTimeOriginal="2021-03-04T05:06:07Z"/></x:xmpmeta>`
+	if err := os.WriteFile(imgPath+".xmp", []byte(xmp), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := dateFromXMPSidecar(imgPath)
+	if err != nil {
+		t.Fatalf("dateFromXMPSidecar: %v", err)
+	}
+
+	want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("dateFromXMPSidecar() = %v, want %v", got, want)
+	}
+}
+
+func TestDateFromExifHeicFallsBackToExiftool(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.heic")
+	if err := os.WriteFile(path, []byte("not a real heic"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// goexif can't parse HEIC at all, so dateFromExif must route through
+	// exiftoolDateTimeOriginal rather than returning goexif's decode error
+	// directly. exiftool isn't installed in this environment, so this only
+	// asserts the fallback was attempted (the error names exiftool, not
+	// goexif's "invalid format" error).
+	_, err := dateFromExif(path)
+	if err == nil {
+		t.Fatal("dateFromExif(heic) with no real content: want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "exiftool") {
+		t.Errorf("dateFromExif(heic) error = %q, want it to mention exiftool", err)
+	}
+}
+
+func TestDateFromXMPSidecarMissing(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(imgPath, []byte("not a real jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dateFromXMPSidecar(imgPath); err == nil {
+		t.Error("dateFromXMPSidecar() with no sidecar: want error, got nil")
+	}
+}
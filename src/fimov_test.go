@@ -1,83 +1,41 @@
 package main
 
-import (
-	"flag"
-	"os"
-	"testing"
-)
+import "testing"
 
-func TestParseCLIArgs(t *testing.T) {
+func TestParseDate(t *testing.T) {
 	tests := []struct {
-		args     []string
-		expected struct {
-			start   string
-			end     string
-			name    string
-			keyword string
-		}
+		date    string
+		wantErr bool
 	}{
-		{
-			args: []string{"camera", "--start", "2020-01-01", "--end", "2020-12-31", "--name", "folder-name"},
-			expected: struct {
-				start   string
-				end     string
-				name    string
-				keyword string
-			}{
-				start:   "2020-01-01",
-				end:     "2020-12-31",
-				name:    "folder-name",
-				keyword: "camera",
-			},
-		},
-		{
-			args: []string{"whatsapp", "--start", "2020-01-01", "--name", "folder-name"},
-			expected: struct {
-				start   string
-				end     string
-				name    string
-				keyword string
-			}{
-				start:   "2020-01-01",
-				end:     "",
-				name:    "folder-name",
-				keyword: "whatsapp",
-			},
-		},
-		{
-			args: []string{"camera", "--start", "2020-01-01"},
-			expected: struct {
-				start   string
-				end     string
-				name    string
-				keyword string
-			}{
-				start:   "2020-01-01",
-				end:     "",
-				name:    "",
-				keyword: "camera",
-			},
-		},
+		{date: "2020-01-01", wantErr: false},
+		{date: "2020-12-31", wantErr: false},
+		{date: "not-a-date", wantErr: true},
+		{date: "", wantErr: true},
 	}
 
 	for _, test := range tests {
-		// Reset the command-line flags for each test case
-		flag.CommandLine = flag.NewFlagSet(test.args[0], flag.ExitOnError)
-		os.Args = append([]string{"cmd"}, test.args...)
+		_, err := parseDate(test.date)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseDate(%q) error = %v, wantErr %v", test.date, err, test.wantErr)
+		}
+	}
+}
 
-		start, end, name, keyword := parseCLIArgs()
+func TestMatchesExtensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions []string
+		want       bool
+	}{
+		{name: "IMG_0001.JPG", extensions: nil, want: true},
+		{name: "IMG_0001.JPG", extensions: []string{".jpg"}, want: true},
+		{name: "clip.mp4", extensions: []string{".jpg", ".mp4"}, want: true},
+		{name: "clip.mov", extensions: []string{".jpg", ".mp4"}, want: false},
+	}
 
-		if start != test.expected.start {
-			t.Errorf("Expected start %s, got %s", test.expected.start, start)
-		}
-		if end != test.expected.end {
-			t.Errorf("Expected end %s, got %s", test.expected.end, end)
-		}
-		if name != test.expected.name {
-			t.Errorf("Expected name %s, got %s", test.expected.name, name)
-		}
-		if keyword != test.expected.keyword {
-			t.Errorf("Expected keyword %s, got %s", test.expected.keyword, keyword)
+	for _, test := range tests {
+		if got := matchesExtensions(test.name, test.extensions); got != test.want {
+			t.Errorf("matchesExtensions(%q, %v) = %v, want %v", test.name, test.extensions, got, test.want)
 		}
 	}
 }
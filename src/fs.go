@@ -0,0 +1,71 @@
+package main
+
+// Fs abstracts destination storage so images can be organized onto the
+// local disk, a WebDAV share, an SFTP server, or (eventually) S3-compatible
+// object storage without the move/layout logic caring which one it is
+// talking to. Source files are always read from the local disk (that's
+// where cameras and phones mount); only the destination is pluggable.
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Fs is the subset of filesystem operations the organizer needs against a
+// destination backend. Source files are always walked on the local disk
+// (see moveImages), so Fs has no Walk method of its own.
+type Fs interface {
+	Stat(name string) (os.FileInfo, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Open(name string) (io.ReadCloser, error)
+	Create(name string) (io.WriteCloser, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+}
+
+// errNotImplemented is returned by backend stubs for operations that are not
+// wired up yet.
+var errNotImplemented = fmt.Errorf("not implemented")
+
+// newFs constructs the destination backend configured for a keyword. An
+// empty or "local" type uses the local disk, which is also what every
+// keyword used before backends existed.
+func newFs(conf PathConfig) (Fs, error) {
+	switch conf.Type {
+	case "", "local":
+		return newLocalFs(), nil
+	case "webdav":
+		return newWebdavFs(conf.URL, conf.User, conf.Password)
+	case "sftp":
+		return newSFTPFs(conf)
+	case "s3":
+		return newS3Fs(conf)
+	default:
+		return nil, fmt.Errorf("unknown destination type: %s", conf.Type)
+	}
+}
+
+// fsLink makes dstPath on fs point at the bytes already stored at
+// contentPath, used by the "cas" layout. Local backends use a symlink;
+// backends without link support fall back to a plain copy.
+func fsLink(fs Fs, contentPath, dstPath string) error {
+	if lf, ok := fs.(*localFs); ok {
+		return os.Symlink(lf.resolve(contentPath), lf.resolve(dstPath))
+	}
+
+	src, err := fs.Open(contentPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := fs.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,194 @@
+package main
+
+// Journal records every move moveImages actually performs as a JSON file
+// next to the organized library, so a bad date filter or layout choice can
+// be undone with "fimov undo <journal-file>" instead of hand-sorting files
+// back by looking at timestamps.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const journalFileName = ".fimov-journal.json"
+
+// JournalEntry is one completed move.
+type JournalEntry struct {
+	Src       string    `json:"src"`
+	Dst       string    `json:"dst"`
+	Hash      string    `json:"hash,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// journalBackend captures the destination backend config a journal was
+// recorded against, so "fimov undo" can reconstruct the same Fs (via newFs)
+// instead of assuming every Dst is a local path.
+type journalBackend struct {
+	Type     string `json:"type,omitempty"`
+	URL      string `json:"url,omitempty"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// fs builds the Fs the entries in this backend were recorded against.
+func (b journalBackend) fs() (Fs, error) {
+	return newFs(PathConfig{Type: b.Type, URL: b.URL, User: b.User, Password: b.Password})
+}
+
+// journalFile is the on-disk shape of a journal: the backend it was
+// recorded against plus the entries themselves.
+type journalFile struct {
+	Backend journalBackend `json:"backend"`
+	Entries []JournalEntry `json:"entries"`
+}
+
+// Journal accumulates entries for a run and flushes them to disk on every
+// record, merging with any entries already recorded under destPath by
+// earlier runs against the same destination.
+type Journal struct {
+	path    string
+	mu      sync.Mutex
+	backend journalBackend
+	entries []JournalEntry
+}
+
+// openJournal loads the journal already present at destPath, if any, so
+// repeated runs against the same destination accumulate one replayable
+// history. conf identifies the destination backend entries are recorded
+// against; for a brand new journal it is stamped in so undo can rebuild the
+// same Fs later.
+func openJournal(destPath string, conf PathConfig) (*Journal, error) {
+	path := filepath.Join(destPath, journalFileName)
+
+	j := &Journal{path: path, backend: journalBackend{Type: conf.Type, URL: conf.URL, User: conf.User, Password: conf.Password}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return j, nil
+		}
+		return nil, err
+	}
+
+	var file journalFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	j.backend = file.Backend
+	j.entries = file.Entries
+
+	return j, nil
+}
+
+// Record appends an entry and flushes the journal to disk immediately, so a
+// process killed mid-run still leaves an accurate, replayable journal.
+func (j *Journal) Record(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = append(j.entries, entry)
+
+	data, err := json.MarshalIndent(journalFile{Backend: j.backend, Entries: j.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(j.path, data, 0o644)
+}
+
+// loadJournal reads a journal file for "fimov undo".
+func loadJournal(path string) (journalBackend, []JournalEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return journalBackend{}, nil, err
+	}
+
+	var file journalFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return journalBackend{}, nil, err
+	}
+
+	return file.Backend, file.Entries, nil
+}
+
+// runUndo replays a journal in reverse, moving files back to their original
+// locations if they still exist at dst. Entries produced by the "cas"
+// layout point at a symlink rather than the physical file; undoing those
+// moves the link back and leaves the content-addressed copy in place, since
+// other dated entries may still reference it. Dst lives on whatever backend
+// the journal was recorded against; Src is always local, matching the Fs
+// abstraction's own assumption that source files never leave local disk.
+func runUndo(journalPath string) error {
+	backend, entries, err := loadJournal(journalPath)
+	if err != nil {
+		return err
+	}
+
+	destFs, err := backend.fs()
+	if err != nil {
+		return fmt.Errorf("reconnecting to destination backend: %w", err)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		if _, err := destFs.Stat(entry.Dst); err != nil {
+			fmt.Printf("Skipping %s: no longer at %s\n", entry.Src, entry.Dst)
+			continue
+		}
+
+		if _, err := os.Lstat(entry.Src); err == nil {
+			fmt.Printf("Skipping %s: already exists, not overwriting\n", entry.Src)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(entry.Src), os.ModePerm); err != nil {
+			return err
+		}
+
+		if lf, ok := destFs.(*localFs); ok {
+			err = os.Rename(lf.resolve(entry.Dst), entry.Src)
+		} else {
+			err = restoreRemote(destFs, entry.Dst, entry.Src)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Restored %s\n", entry.Src)
+	}
+
+	return nil
+}
+
+// restoreRemote copies dst from a non-local destination backend back to the
+// local src path and removes it from the backend, since Fs has no Rename
+// that crosses from a remote backend onto the local disk.
+func restoreRemote(destFs Fs, dst, src string) error {
+	r, err := destFs.Open(dst)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(src)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		os.Remove(src)
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return destFs.Remove(dst)
+}
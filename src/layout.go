@@ -0,0 +1,126 @@
+package main
+
+// Destination layout strategies for moveImages.
+//
+// "flat" is the original behaviour: every file lands directly in destPath.
+// "dated" nests files under destPath/date/YYYY/MM/DD using their resolved date.
+// "cas" stores one physical copy per unique file under a content-addressed
+// directory and links the dated path to it, so duplicates (e.g. the same
+// photo backed up twice) are stored only once.
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Supported values for the "--layout" flag and per-keyword "layout" config field.
+const (
+	layoutFlat  = "flat"
+	layoutDated = "dated"
+	layoutCAS   = "cas"
+)
+
+// destPathFor returns where a file should be placed for the given layout,
+// creating any parent directories it needs on destFs. When dryRun is true
+// no directories are created; the path returned is still the one that would
+// be used. For "cas" only the content and date directories are prepared
+// here; placing the file and detecting duplicates is done by placeCAS.
+func destPathFor(destFs Fs, destPath, layout string, date time.Time, info os.FileInfo, hash string, dryRun bool) (dst string, err error) {
+	switch layout {
+	case layoutDated:
+		dir := filepath.Join(destPath, "date", date.Format("2006/01/02"))
+		if !dryRun {
+			if err := destFs.MkdirAll(dir, os.ModePerm); err != nil {
+				return "", err
+			}
+		}
+		return filepath.Join(dir, info.Name()), nil
+
+	case layoutCAS:
+		contentDir := filepath.Join(destPath, "content", hash[:2])
+		dateDir := filepath.Join(destPath, "date", date.Format("2006/01/02"))
+		if !dryRun {
+			if err := destFs.MkdirAll(contentDir, os.ModePerm); err != nil {
+				return "", err
+			}
+			if err := destFs.MkdirAll(dateDir, os.ModePerm); err != nil {
+				return "", err
+			}
+		}
+		return filepath.Join(dateDir, info.Name()), nil
+
+	default: // layoutFlat
+		return filepath.Join(destPath, info.Name()), nil
+	}
+}
+
+// hashFile returns the lowercase hex SHA-256 digest of a file's contents.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// casContentPath returns the content-addressed storage location for a file
+// with the given hash and extension, e.g. destPath/content/ab/ab12....jpg.
+func casContentPath(destPath, hash, ext string) string {
+	return filepath.Join(destPath, "content", hash[:2], hash+ext)
+}
+
+// placeCAS moves src into the content-addressed store on destFs (if not
+// already present) and links dst to it, reporting how many bytes were saved
+// by not storing a duplicate again and whether src was actually consumed
+// (moved or removed). Callers must only journal the move when moved is
+// true: if the content already existed and dedup is off, src is left
+// exactly where it was, and there is nothing for undo to reverse.
+func placeCAS(destFs Fs, src, destPath, dst, hash, verify string, dedup bool) (dupBytes int64, moved bool, err error) {
+	ext := filepath.Ext(src)
+	contentPath := casContentPath(destPath, hash, ext)
+
+	if _, statErr := destFs.Stat(contentPath); statErr == nil {
+		// Content already stored; link the dated path to it rather than
+		// copying the incoming duplicate again.
+		info, err := os.Stat(src)
+		if err != nil {
+			return 0, false, err
+		}
+		if !dedup {
+			// src stays put; only the link was created, so there's
+			// nothing to journal or undo for this file.
+			if err := fsLink(destFs, contentPath, dst); err != nil {
+				return 0, false, err
+			}
+			return info.Size(), false, nil
+		}
+		if err := os.Remove(src); err != nil {
+			return 0, false, err
+		}
+		if err := fsLink(destFs, contentPath, dst); err != nil {
+			return 0, false, err
+		}
+		return info.Size(), true, nil
+	}
+
+	if err := safeMove(destFs, src, contentPath, verify); err != nil {
+		return 0, false, err
+	}
+
+	if err := fsLink(destFs, contentPath, dst); err != nil {
+		return 0, false, err
+	}
+
+	return 0, true, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDestPathFor(t *testing.T) {
+	destFs := newLocalFs()
+	date := time.Date(2022, 5, 6, 0, 0, 0, 0, time.UTC)
+	info, err := os.Stat(writeTempFile(t, "photo.jpg", "content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		layout string
+		want   []string // path segments relative to destPath
+	}{
+		{layout: layoutFlat, want: []string{"photo.jpg"}},
+		{layout: layoutDated, want: []string{"date", "2022", "05", "06", "photo.jpg"}},
+	}
+
+	for _, test := range tests {
+		destPath := t.TempDir()
+		got, err := destPathFor(destFs, destPath, test.layout, date, info, "", false)
+		if err != nil {
+			t.Fatalf("destPathFor(%s): %v", test.layout, err)
+		}
+		want := filepath.Join(append([]string{destPath}, test.want...)...)
+		if got != want {
+			t.Errorf("destPathFor(%s) = %q, want %q", test.layout, got, want)
+		}
+	}
+}
+
+func TestDestPathForCASCreatesContentAndDateDirs(t *testing.T) {
+	destPath := t.TempDir()
+	destFs := newLocalFs()
+	date := time.Date(2022, 5, 6, 0, 0, 0, 0, time.UTC)
+	info, err := os.Stat(writeTempFile(t, "photo.jpg", "content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := destPathFor(destFs, destPath, layoutCAS, date, info, "abcd1234", false)
+	if err != nil {
+		t.Fatalf("destPathFor: %v", err)
+	}
+
+	want := filepath.Join(destPath, "date", "2022", "05", "06", "photo.jpg")
+	if got != want {
+		t.Errorf("destPathFor(cas) = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(destPath, "content", "ab")); err != nil {
+		t.Errorf("content dir not created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destPath, "date", "2022", "05", "06")); err != nil {
+		t.Errorf("date dir not created: %v", err)
+	}
+}
+
+func TestPlaceCAS(t *testing.T) {
+	destPath := t.TempDir()
+	destFs := newLocalFs()
+
+	first := writeTempFile(t, "first.jpg", "same bytes")
+	hash, err := hashFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstDst := filepath.Join(destPath, "date", "2022", "05", "06", "first.jpg")
+	if err := os.MkdirAll(filepath.Dir(firstDst), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(destPath, "content", hash[:2]), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, moved, err := placeCAS(destFs, first, destPath, firstDst, hash, verifyNone, false)
+	if err != nil {
+		t.Fatalf("placeCAS (first): %v", err)
+	}
+	if saved != 0 || !moved {
+		t.Errorf("placeCAS (first) = saved %d, moved %v, want 0, true", saved, moved)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("first source should have been moved into the content store: err = %v", err)
+	}
+
+	// A second, byte-identical file: dedup=false must leave its source
+	// untouched and only link its dst into the existing content store.
+	second := writeTempFile(t, "second.jpg", "same bytes")
+	secondDst := filepath.Join(destPath, "date", "2022", "05", "07", "second.jpg")
+	if err := os.MkdirAll(filepath.Dir(secondDst), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, moved, err = placeCAS(destFs, second, destPath, secondDst, hash, verifyNone, false)
+	if err != nil {
+		t.Fatalf("placeCAS (duplicate, dedup=false): %v", err)
+	}
+	if moved {
+		t.Error("placeCAS (duplicate, dedup=false): moved = true, want false (nothing to journal)")
+	}
+	if saved == 0 {
+		t.Error("placeCAS (duplicate, dedup=false): saved = 0, want bytes saved reported")
+	}
+	if _, err := os.Stat(second); err != nil {
+		t.Errorf("second source should be untouched when dedup=false: %v", err)
+	}
+	if _, err := os.Lstat(secondDst); err != nil {
+		t.Errorf("second dst should be linked into the content store: %v", err)
+	}
+
+	// A third duplicate with dedup=true: its source is consumed, matching
+	// the "skip/link" promise of --dedup.
+	third := writeTempFile(t, "third.jpg", "same bytes")
+	thirdDst := filepath.Join(destPath, "date", "2022", "05", "08", "third.jpg")
+	if err := os.MkdirAll(filepath.Dir(thirdDst), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	_, moved, err = placeCAS(destFs, third, destPath, thirdDst, hash, verifyNone, true)
+	if err != nil {
+		t.Fatalf("placeCAS (duplicate, dedup=true): %v", err)
+	}
+	if !moved {
+		t.Error("placeCAS (duplicate, dedup=true): moved = false, want true")
+	}
+	if _, err := os.Stat(third); !os.IsNotExist(err) {
+		t.Errorf("third source should have been removed when dedup=true: err = %v", err)
+	}
+}
+
+// writeTempFile writes content to name under a fresh t.TempDir() and
+// returns its path.
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
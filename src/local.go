@@ -0,0 +1,46 @@
+package main
+
+// localFs is the default Fs backend: the destination lives on the same
+// machine, so every call is a thin wrapper around the os package.
+
+import (
+	"io"
+	"os"
+)
+
+type localFs struct{}
+
+func newLocalFs() *localFs {
+	return &localFs{}
+}
+
+// resolve is a no-op for the local backend but gives safeMove/fsLink a single
+// place to turn a destination-relative path into the path os.Rename and
+// os.Symlink expect.
+func (l *localFs) resolve(name string) string {
+	return name
+}
+
+func (l *localFs) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (l *localFs) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (l *localFs) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+func (l *localFs) Create(name string) (io.WriteCloser, error) {
+	return os.Create(name)
+}
+
+func (l *localFs) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (l *localFs) Remove(name string) error {
+	return os.Remove(name)
+}
@@ -0,0 +1,326 @@
+package main
+
+// Parallel move pipeline: one walker goroutine feeds parser workers, parser
+// workers feed mover workers, modeled after staged arrange-style pipelines
+// so a large library saturates I/O instead of being processed one file at a
+// time. Ctrl-C cancels the pipeline cleanly between stages.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pipelineFile carries a candidate file between pipeline stages once its
+// date has been resolved.
+type pipelineFile struct {
+	path       string
+	info       os.FileInfo
+	date       time.Time
+	dateSource string
+}
+
+// pipelineOptions configures worker counts and move safety for moveImages.
+type pipelineOptions struct {
+	Workers      int
+	ParseWorkers int
+	Verify       string
+	DryRun       bool
+	Journal      *Journal
+	Extensions   []string
+}
+
+// matchesExtensions reports whether name's extension is in extensions
+// (case-insensitive). An empty extensions list matches everything.
+func matchesExtensions(name string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return true
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, allowed := range extensions {
+		if strings.ToLower(strings.TrimSpace(allowed)) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	defaultWorkers      = 4
+	defaultParseWorkers = 4
+)
+
+// dedupTracker records which content hashes have already been placed during
+// a single moveImages run, so --dedup has a real effect for the "flat" and
+// "dated" layouts too: they have no content-addressed store to dedup
+// against like "cas" does, so this is what lets a second copy of the same
+// file be linked to the first instead of copied again.
+type dedupTracker struct {
+	mu   sync.Mutex
+	seen map[string]string
+}
+
+func newDedupTracker() *dedupTracker {
+	return &dedupTracker{seen: make(map[string]string)}
+}
+
+// claimOrRecord reports the destination an earlier file with this hash was
+// already placed at, if any. Otherwise it records dst as that place for
+// future duplicates and returns ok=false.
+func (d *dedupTracker) claimOrRecord(hash, dst string) (existing string, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if existing, ok := d.seen[hash]; ok {
+		return existing, true
+	}
+	d.seen[hash] = dst
+	return "", false
+}
+
+// hashLocks hands out one mutex per content hash for the lifetime of a
+// moveImages run, so mover workers placing two byte-identical files never
+// run placeCAS's check-then-act "does this content already exist" logic
+// concurrently for the same hash.
+type hashLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newHashLocks() *hashLocks {
+	return &hashLocks{locks: make(map[string]*sync.Mutex)}
+}
+
+// lock blocks until it holds the lock for hash and returns a func to release it.
+func (h *hashLocks) lock(hash string) func() {
+	h.mu.Lock()
+	l, ok := h.locks[hash]
+	if !ok {
+		l = &sync.Mutex{}
+		h.locks[hash] = l
+	}
+	h.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// moveImages walks source in its own goroutine, hands each candidate file to
+// a pool of parse workers that resolve its capture date, and hands accepted
+// files to a pool of mover workers that place them at the destination. It
+// prints running progress and can be aborted with ctrl-C.
+func moveImages(destFs Fs, source, destPath string, startDate, endDate time.Time, dateSources []string, layout string, dedup bool, opts pipelineOptions) (int64, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.ParseWorkers <= 0 {
+		opts.ParseWorkers = defaultParseWorkers
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	paths := make(chan string)
+	files := make(chan pipelineFile)
+
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+	}()
+
+	var (
+		processed  int64
+		bytesMoved int64
+		dupBytes   int64
+		failed     int64
+		moveWG     sync.WaitGroup
+	)
+
+	var parseWG sync.WaitGroup
+	for i := 0; i < opts.ParseWorkers; i++ {
+		parseWG.Add(1)
+		go func() {
+			defer parseWG.Done()
+			for path := range paths {
+				if !matchesExtensions(path, opts.Extensions) {
+					continue
+				}
+
+				info, err := os.Stat(path)
+				if err != nil {
+					fmt.Println("Error stating file:", err)
+					atomic.AddInt64(&failed, 1)
+					continue
+				}
+
+				date, usedSource, err := resolveDate(path, info, dateSources)
+				if err != nil {
+					fmt.Println("Error resolving date:", err)
+					atomic.AddInt64(&failed, 1)
+					continue
+				}
+
+				if !date.After(startDate) || !date.Before(endDate) {
+					continue
+				}
+
+				select {
+				case files <- pipelineFile{path: path, info: info, date: date, dateSource: usedSource}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		parseWG.Wait()
+		close(files)
+	}()
+
+	var tracker *dedupTracker
+	if dedup && layout != layoutCAS {
+		tracker = newDedupTracker()
+	}
+
+	var casLocks *hashLocks
+	if layout == layoutCAS {
+		casLocks = newHashLocks()
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		moveWG.Add(1)
+		go func() {
+			defer moveWG.Done()
+			for f := range files {
+				var hash string
+				var err error
+				if layout == layoutCAS || dedup {
+					hash, err = hashFile(f.path)
+					if err != nil {
+						fmt.Println("Error hashing file:", err)
+						atomic.AddInt64(&failed, 1)
+						continue
+					}
+				}
+
+				destFile, err := destPathFor(destFs, destPath, layout, f.date, f.info, hash, opts.DryRun)
+				if err != nil {
+					fmt.Println("Error preparing destination:", err)
+					atomic.AddInt64(&failed, 1)
+					continue
+				}
+
+				if tracker != nil {
+					if existing, dup := tracker.claimOrRecord(hash, destFile); dup {
+						if opts.DryRun {
+							fmt.Printf("[dry-run] %s -> %s (duplicate of %s)\n", f.path, destFile, existing)
+							atomic.AddInt64(&processed, 1)
+							continue
+						}
+
+						if err := fsLink(destFs, existing, destFile); err != nil {
+							fmt.Println("Error linking duplicate file:", err)
+							atomic.AddInt64(&failed, 1)
+							continue
+						}
+						if err := os.Remove(f.path); err != nil {
+							fmt.Println("Error removing duplicate file:", err)
+							atomic.AddInt64(&failed, 1)
+							continue
+						}
+
+						if opts.Journal != nil {
+							entry := JournalEntry{Src: f.path, Dst: destFile, Hash: hash, Timestamp: time.Now()}
+							if err := opts.Journal.Record(entry); err != nil {
+								fmt.Println("Error recording journal entry:", err)
+								atomic.AddInt64(&failed, 1)
+							}
+						}
+
+						atomic.AddInt64(&dupBytes, f.info.Size())
+						atomic.AddInt64(&processed, 1)
+						fmt.Printf("\r[%d moved, %d bytes] %s (duplicate of %s)",
+							atomic.LoadInt64(&processed), atomic.LoadInt64(&bytesMoved), f.info.Name(), existing)
+						continue
+					}
+				}
+
+				if opts.DryRun {
+					fmt.Printf("[dry-run] %s -> %s (date source: %s)\n", f.path, destFile, f.dateSource)
+					atomic.AddInt64(&processed, 1)
+					continue
+				}
+
+				moved := true
+				if layout == layoutCAS {
+					unlock := casLocks.lock(hash)
+					saved, casMoved, err := placeCAS(destFs, f.path, destPath, destFile, hash, opts.Verify, dedup)
+					unlock()
+					if err != nil {
+						fmt.Println("Error moving file:", err)
+						atomic.AddInt64(&failed, 1)
+						continue
+					}
+					atomic.AddInt64(&dupBytes, saved)
+					moved = casMoved
+				} else if err := safeMove(destFs, f.path, destFile, opts.Verify); err != nil {
+					fmt.Println("Error moving file:", err)
+					atomic.AddInt64(&failed, 1)
+					continue
+				}
+
+				if opts.Journal != nil && moved {
+					entry := JournalEntry{Src: f.path, Dst: destFile, Hash: hash, Timestamp: time.Now()}
+					if err := opts.Journal.Record(entry); err != nil {
+						fmt.Println("Error recording journal entry:", err)
+						atomic.AddInt64(&failed, 1)
+					}
+				}
+
+				atomic.AddInt64(&processed, 1)
+				total := atomic.AddInt64(&bytesMoved, f.info.Size())
+				fmt.Printf("\r[%d moved, %d bytes] %s (date source: %s)",
+					atomic.LoadInt64(&processed), total, f.info.Name(), f.dateSource)
+			}
+		}()
+	}
+
+	moveWG.Wait()
+	fmt.Println()
+
+	var errs []error
+	if ctx.Err() != nil {
+		errs = append(errs, fmt.Errorf("aborted: %w", ctx.Err()))
+	}
+	if walkErr != nil {
+		errs = append(errs, walkErr)
+	}
+	if n := atomic.LoadInt64(&failed); n > 0 {
+		errs = append(errs, fmt.Errorf("%d file(s) failed, see output above", n))
+	}
+
+	return dupBytes, errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDedupTrackerClaimOrRecord(t *testing.T) {
+	d := newDedupTracker()
+
+	if existing, dup := d.claimOrRecord("hash1", "/dest/a.jpg"); dup {
+		t.Errorf("first claim for hash1: got dup=true (existing %q), want dup=false", existing)
+	}
+
+	existing, dup := d.claimOrRecord("hash1", "/dest/b.jpg")
+	if !dup {
+		t.Fatal("second claim for hash1: want dup=true")
+	}
+	if existing != "/dest/a.jpg" {
+		t.Errorf("second claim for hash1: existing = %q, want %q", existing, "/dest/a.jpg")
+	}
+
+	if _, dup := d.claimOrRecord("hash2", "/dest/c.jpg"); dup {
+		t.Error("first claim for hash2: want dup=false")
+	}
+}
+
+// TestMoveImagesCASConcurrentDuplicates reproduces the race two mover
+// workers used to hit when placing byte-identical files under layout=cas at
+// the same time: both would see the content store empty, both would try to
+// claim it, and the loser's fsLink would fail with "file exists" after its
+// source had already been consumed, losing the file. Each pair below shares
+// content but not a destination filename, so all four must survive.
+func TestMoveImagesCASConcurrentDuplicates(t *testing.T) {
+	source := t.TempDir()
+	destPath := t.TempDir()
+
+	const pairs = 4
+	for i := 0; i < pairs; i++ {
+		content := []byte{byte(i), byte(i), byte(i)}
+		for _, suffix := range []string{"a", "b"} {
+			name := filepath.Join(source, fmtName(i, suffix))
+			if err := os.WriteFile(name, content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	start := time.Now().Add(-time.Hour)
+	end := time.Now().Add(time.Hour)
+
+	opts := pipelineOptions{Workers: 8, ParseWorkers: 8, Verify: verifySHA256}
+	if _, err := moveImages(newLocalFs(), source, destPath, start, end, []string{dateSourceMtime}, layoutCAS, false, opts); err != nil {
+		t.Fatalf("moveImages: %v", err)
+	}
+
+	for i := 0; i < pairs; i++ {
+		for _, suffix := range []string{"a", "b"} {
+			dst := filepath.Join(destPath, "date", time.Now().Format("2006/01/02"), fmtName(i, suffix))
+			if _, err := os.Lstat(dst); err != nil {
+				t.Errorf("pair %d%s: dst missing: %v", i, suffix, err)
+			}
+		}
+	}
+
+	content, err := os.ReadDir(filepath.Join(destPath, "content"))
+	if err != nil {
+		t.Fatalf("reading content dir: %v", err)
+	}
+	blobs := 0
+	for _, shard := range content {
+		entries, err := os.ReadDir(filepath.Join(destPath, "content", shard.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs += len(entries)
+	}
+	if blobs != pairs {
+		t.Errorf("content store has %d blobs, want %d (one per pair, duplicates linked not copied)", blobs, pairs)
+	}
+}
+
+func fmtName(i int, suffix string) string {
+	return string(rune('0'+i)) + suffix + ".jpg"
+}
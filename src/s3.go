@@ -0,0 +1,14 @@
+package main
+
+// s3.go is an extension point for an S3-compatible object storage
+// destination backend. Object stores don't have real directories or renames,
+// so Fs's MkdirAll/Rename would need to be simulated with key prefixes; that
+// mapping is left for when a concrete use case needs it.
+
+import "fmt"
+
+type s3Fs struct{}
+
+func newS3Fs(conf PathConfig) (Fs, error) {
+	return nil, fmt.Errorf("s3 destination backend is %w yet", errNotImplemented)
+}
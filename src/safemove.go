@@ -0,0 +1,142 @@
+package main
+
+// safeMove moves a local source file onto a destination backend. It is the
+// only place os.Rename's EXDEV failure gets handled (very common when
+// moving from an SD card mount to a NAS folder): on that error the file is
+// streamed to a temporary destination, fsynced, verified, and only then
+// swapped into place with the source removed. Any failure along the way
+// leaves the source untouched and cleans up the partial destination.
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// Supported values for the "--verify" flag.
+const (
+	verifyNone   = "none"
+	verifySize   = "size"
+	verifySHA256 = "sha256"
+)
+
+func safeMove(destFs Fs, srcPath, dstPath, verify string) error {
+	if lf, ok := destFs.(*localFs); ok {
+		err := os.Rename(srcPath, lf.resolve(dstPath))
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+		// Cross-device rename: fall through to the streaming copy below.
+	}
+
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := dstPath + ".tmp"
+	if err := streamCopy(destFs, srcPath, tmpPath); err != nil {
+		destFs.Remove(tmpPath)
+		return err
+	}
+
+	if err := verifyCopy(destFs, srcPath, tmpPath, srcInfo, verify); err != nil {
+		destFs.Remove(tmpPath)
+		return err
+	}
+
+	if err := destFs.Rename(tmpPath, dstPath); err != nil {
+		destFs.Remove(tmpPath)
+		return err
+	}
+
+	return os.Remove(srcPath)
+}
+
+// streamCopy copies srcPath to dstPath on destFs and fsyncs the destination
+// before closing it, when the backend supports that.
+func streamCopy(destFs Fs, srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := destFs.Create(dstPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	if syncer, ok := dst.(interface{ Sync() error }); ok {
+		if err := syncer.Sync(); err != nil {
+			dst.Close()
+			return err
+		}
+	}
+
+	return dst.Close()
+}
+
+// verifyCopy checks that dstPath on destFs matches srcInfo according to the
+// requested verify mode.
+func verifyCopy(destFs Fs, srcPath, dstPath string, srcInfo os.FileInfo, verify string) error {
+	switch verify {
+	case verifyNone, "":
+		return nil
+
+	case verifySize:
+		dstInfo, err := destFs.Stat(dstPath)
+		if err != nil {
+			return err
+		}
+		if dstInfo.Size() != srcInfo.Size() {
+			return fmt.Errorf("size mismatch after copy: src %d bytes, dst %d bytes", srcInfo.Size(), dstInfo.Size())
+		}
+		return nil
+
+	case verifySHA256:
+		srcHash, err := hashFile(srcPath)
+		if err != nil {
+			return err
+		}
+		dstHash, err := hashFs(destFs, dstPath)
+		if err != nil {
+			return err
+		}
+		if srcHash != dstHash {
+			return fmt.Errorf("hash mismatch after copy: src %s, dst %s", srcHash, dstHash)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown verify mode: %s", verify)
+	}
+}
+
+// hashFs returns the lowercase hex SHA-256 digest of a file read through a
+// destination backend.
+func hashFs(fs Fs, path string) (string, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
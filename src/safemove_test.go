@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeMoveLocalRename(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.jpg")
+	dst := filepath.Join(dir, "dst.jpg")
+	if err := os.WriteFile(src, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := safeMove(newLocalFs(), src, dst, verifyNone); err != nil {
+		t.Fatalf("safeMove: %v", err)
+	}
+
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("src still exists after safeMove: err = %v", err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("reading dst: %v", err)
+	}
+	if string(data) != "content" {
+		t.Errorf("dst content = %q, want %q", data, "content")
+	}
+}
+
+func TestVerifyCopy(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.jpg")
+	match := filepath.Join(dir, "match.jpg")
+	mismatch := filepath.Join(dir, "mismatch.jpg")
+	if err := os.WriteFile(src, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(match, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mismatch, []byte("different"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	destFs := newLocalFs()
+	tests := []struct {
+		name    string
+		dst     string
+		verify  string
+		wantErr bool
+	}{
+		{name: "none", dst: mismatch, verify: verifyNone, wantErr: false},
+		{name: "size match", dst: match, verify: verifySize, wantErr: false},
+		{name: "size mismatch", dst: mismatch, verify: verifySize, wantErr: true},
+		{name: "sha256 match", dst: match, verify: verifySHA256, wantErr: false},
+		{name: "sha256 mismatch", dst: mismatch, verify: verifySHA256, wantErr: true},
+		{name: "unknown", dst: match, verify: "bogus", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyCopy(destFs, src, test.dst, srcInfo, test.verify)
+			if (err != nil) != test.wantErr {
+				t.Errorf("verifyCopy(verify=%s) error = %v, wantErr %v", test.verify, err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+// webdavFs stores the destination library on a WebDAV share (e.g. a NAS),
+// so users can organize directly onto it without pre-mounting it as a local
+// filesystem. This mirrors how rclone and syncthing separate filesystem
+// access from higher-level sync/organize logic.
+
+import (
+	"io"
+	"os"
+
+	"github.com/studio-b12/gowebdav"
+)
+
+type webdavFs struct {
+	client *gowebdav.Client
+}
+
+func newWebdavFs(url, user, password string) (*webdavFs, error) {
+	client := gowebdav.NewClient(url, user, password)
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+
+	return &webdavFs{client: client}, nil
+}
+
+func (w *webdavFs) Stat(name string) (os.FileInfo, error) {
+	return w.client.Stat(name)
+}
+
+func (w *webdavFs) MkdirAll(path string, perm os.FileMode) error {
+	return w.client.MkdirAll(path, perm)
+}
+
+func (w *webdavFs) Open(name string) (io.ReadCloser, error) {
+	return w.client.ReadStream(name)
+}
+
+func (w *webdavFs) Create(name string) (io.WriteCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(w.client.WriteStream(name, pr, 0o644))
+	}()
+	return pw, nil
+}
+
+func (w *webdavFs) Rename(oldpath, newpath string) error {
+	return w.client.Rename(oldpath, newpath, true)
+}
+
+func (w *webdavFs) Remove(name string) error {
+	return w.client.Remove(name)
+}